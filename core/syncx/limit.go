@@ -1,9 +1,9 @@
 package syncx
 
 import (
+	"context"
 	"errors"
-
-	"github.com/zeromicro/go-zero/core/lang"
+	"sync"
 )
 
 /**
@@ -17,42 +17,110 @@ import (
 // ErrLimitReturn indicates that the more than borrowed elements were returned.
 var ErrLimitReturn = errors.New("discarding limited token, resource pool is full, someone returned multiple times")
 
-// Limit controls the concurrent requests.
-type Limit struct {
-	pool chan lang.PlaceholderType
-}
+type (
+	// Observer gets notified about the token lifecycle of a Limit, so that callers can plumb
+	// metrics (e.g. Prometheus counters) for saturation and rejection rate. The callbacks take
+	// no arguments and nothing is reported when a borrow starts waiting, so wait time is not observed.
+	Observer interface {
+		// OnBorrow is called every time tokens are successfully borrowed.
+		OnBorrow()
+		// OnReturn is called every time tokens are successfully returned.
+		OnReturn()
+		// OnReject is called every time a non-blocking or context-bound borrow fails.
+		OnReject()
+	}
+
+	// [rtfsc]
+	// total    信号量总量
+	// inUse    已经借出的数量
+	// cond     用于在借不到信号量时阻塞等待，归还时唤醒等待者
+	// observer 可选的观察者，为空时不上报
+	// [end]
+	limitCore struct {
+		lock     sync.Mutex
+		cond     *sync.Cond
+		total    int
+		inUse    int
+		observer Observer
+	}
+
+	// Limit controls the concurrent requests.
+	Limit struct {
+		core *limitCore
+	}
+)
 
 // [rtfsc]
 // 创建一个限制器，并规定总的数量
+// 之所以把可变状态（total/inUse/cond）放进内部的 *limitCore，是因为要支持 BorrowN 这种
+// “一次性借多个、借不到就都不借”的原子操作，单纯靠 channel 发送 n 次无法做到，容易死锁
 // [end]
 // NewLimit creates a Limit that can borrow n elements from it concurrently.
 func NewLimit(n int) Limit {
-	return Limit{
-		pool: make(chan lang.PlaceholderType, n),
+	return NewLimitWithObserver(n, nil)
+}
+
+// NewLimitWithObserver creates a Limit like NewLimit, but reports borrow/return/reject
+// events to the given Observer.
+func NewLimitWithObserver(n int, observer Observer) Limit {
+	core := &limitCore{
+		total:    n,
+		observer: observer,
 	}
+	core.cond = sync.NewCond(&core.lock)
+
+	return Limit{core: core}
 }
 
 // [rtfsc]
-// 占用一个信号量，通过向 channel 写入实现。
-// 利用 channel 的特性，channel 满了之后再写入，就得等待
-// 以此来实现限制的作用
+// 占用一个信号量，借不到就阻塞等待
 // [end]
 // Borrow borrows an element from Limit in blocking mode.
 func (l Limit) Borrow() {
-	l.pool <- lang.Placeholder
+	l.BorrowN(1)
+}
+
+// [rtfsc]
+// 一次性借用 n 个信号量，要么全部借到，要么继续等待，不会出现借到一半的情况
+// [end]
+// BorrowN borrows n elements from Limit in blocking mode, all-or-nothing.
+func (l Limit) BorrowN(n int) {
+	c := l.core
+	c.lock.Lock()
+	for c.total-c.inUse < n {
+		c.cond.Wait()
+	}
+	c.inUse += n
+	c.lock.Unlock()
+
+	c.notifyBorrow()
 }
 
 // [rtfsc]
 // 归还信号量
+//
+// 这里必须用 Broadcast 而不是 Signal：BorrowN 允许不同协程等待不同的 n，Signal 只会唤醒
+// 其中一个等待者，而被唤醒的那个如果恰好是一个 n 比较大、当前仍然不够的 BorrowN 等待者，
+// 它会发现条件不满足又重新进入 Wait，于是这次 Return 腾出来的名额就被白白唤醒、没有任何
+// 等待者真正消费到，其他本来已经够用的等待者（比如 Borrow）却继续沉睡，造成饥饿甚至永久
+// 阻塞。Broadcast 唤醒所有等待者，让它们各自重新判断自己的 n 是否已经满足，谁的条件满足
+// 谁就能继续，不会被条件不满足的那个"抢走"这次唤醒。
 // [end]
 // Return returns the borrowed resource, returns error only if returned more than borrowed.
 func (l Limit) Return() error {
-	select {
-	case <-l.pool:
-		return nil
-	default:
+	c := l.core
+	c.lock.Lock()
+	if c.inUse == 0 {
+		c.lock.Unlock()
 		return ErrLimitReturn
 	}
+
+	c.inUse--
+	c.cond.Broadcast()
+	c.lock.Unlock()
+
+	c.notifyReturn()
+	return nil
 }
 
 // [rtfsc]
@@ -61,10 +129,106 @@ func (l Limit) Return() error {
 // TryBorrow tries to borrow an element from Limit, in non-blocking mode.
 // If success, true returned, false for otherwise.
 func (l Limit) TryBorrow() bool {
-	select {
-	case l.pool <- lang.Placeholder:
-		return true
-	default:
+	return l.TryBorrowN(1)
+}
+
+// [rtfsc]
+// 非阻塞地一次性尝试借用 n 个信号量，不够则什么都不借，直接返回 false
+// [end]
+// TryBorrowN tries to borrow n elements from Limit, all-or-nothing, in non-blocking mode.
+func (l Limit) TryBorrowN(n int) bool {
+	c := l.core
+	c.lock.Lock()
+	if c.total-c.inUse < n {
+		c.lock.Unlock()
+		c.notifyReject()
 		return false
 	}
+
+	c.inUse += n
+	c.lock.Unlock()
+
+	c.notifyBorrow()
+	return true
+}
+
+// [rtfsc]
+// 带 context 的借用：借不到时阻塞等待，但 ctx 被取消/超时后会放弃等待并返回 ctx.Err()
+// 用一个额外的协程在 ctx.Done() 后唤醒 cond，让等待者有机会重新检查 ctx 是否已经结束
+// [end]
+// BorrowCtx borrows an element from Limit in blocking mode, but gives up and returns
+// ctx.Err() once ctx is done.
+func (l Limit) BorrowCtx(ctx context.Context) error {
+	c := l.core
+	c.lock.Lock()
+	if c.total-c.inUse >= 1 {
+		c.inUse++
+		c.lock.Unlock()
+		c.notifyBorrow()
+		return nil
+	}
+	c.lock.Unlock()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.lock.Lock()
+			c.cond.Broadcast()
+			c.lock.Unlock()
+		case <-stop:
+		}
+	}()
+
+	c.lock.Lock()
+	for c.total-c.inUse < 1 {
+		if err := ctx.Err(); err != nil {
+			c.lock.Unlock()
+			c.notifyReject()
+			return err
+		}
+		c.cond.Wait()
+	}
+	c.inUse++
+	c.lock.Unlock()
+
+	c.notifyBorrow()
+	return nil
+}
+
+// Available returns how many elements can still be borrowed without blocking.
+func (l Limit) Available() int {
+	c := l.core
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.total - c.inUse
+}
+
+// InUse returns how many elements are currently borrowed.
+func (l Limit) InUse() int {
+	c := l.core
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.inUse
+}
+
+func (c *limitCore) notifyBorrow() {
+	if c.observer != nil {
+		c.observer.OnBorrow()
+	}
+}
+
+func (c *limitCore) notifyReturn() {
+	if c.observer != nil {
+		c.observer.OnReturn()
+	}
+}
+
+func (c *limitCore) notifyReject() {
+	if c.observer != nil {
+		c.observer.OnReject()
+	}
 }
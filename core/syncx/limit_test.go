@@ -0,0 +1,169 @@
+package syncx
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockObserver struct {
+	borrowed int32
+	returned int32
+	rejected int32
+}
+
+func (o *mockObserver) OnBorrow() {
+	atomic.AddInt32(&o.borrowed, 1)
+}
+
+func (o *mockObserver) OnReturn() {
+	atomic.AddInt32(&o.returned, 1)
+}
+
+func (o *mockObserver) OnReject() {
+	atomic.AddInt32(&o.rejected, 1)
+}
+
+func TestLimit_ReturnWakesSmallerWaiterDespiteBiggerWaiterQueued(t *testing.T) {
+	l := NewLimit(3)
+	l.BorrowN(3)
+
+	big := make(chan struct{})
+	go func() {
+		l.BorrowN(3)
+		close(big)
+	}()
+	// give the BorrowN(3) goroutine a chance to start waiting first.
+	time.Sleep(10 * time.Millisecond)
+
+	small := make(chan struct{})
+	go func() {
+		l.Borrow()
+		close(small)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, l.Return())
+
+	select {
+	case <-small:
+	case <-time.After(time.Second):
+		t.Fatal("Borrow() waiter was starved by a queued BorrowN(3) waiter")
+	}
+
+	select {
+	case <-big:
+		t.Fatal("BorrowN(3) should still be waiting, only 1 token is available")
+	default:
+	}
+
+	// drain the remaining tokens, including the one now held by the small waiter, so the
+	// BorrowN(3) goroutine can finish instead of leaking for the rest of the test run.
+	assert.NoError(t, l.Return())
+	assert.NoError(t, l.Return())
+	assert.NoError(t, l.Return())
+	select {
+	case <-big:
+	case <-time.After(time.Second):
+		t.Fatal("BorrowN(3) never completed after all tokens were returned")
+	}
+}
+
+func TestLimit_ConcurrentBorrowReturn(t *testing.T) {
+	l := NewLimit(4)
+
+	const n = 32
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				l.Borrow()
+				assert.LessOrEqual(t, l.InUse(), 4)
+				assert.NoError(t, l.Return())
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 4, l.Available())
+	assert.Equal(t, 0, l.InUse())
+}
+
+func TestLimit_BorrowCtxTimesOutWithoutLeakingWaiter(t *testing.T) {
+	l := NewLimit(1)
+	l.Borrow()
+
+	runtime.GC()
+	before := runtime.NumGoroutine()
+
+	// run many timeouts: if each leaked its ctx-watcher goroutine, the growth would be
+	// clearly visible (and not just scheduler/GC noise) once things settle.
+	const n = 50
+	for i := 0; i < n; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		err := l.BorrowCtx(ctx)
+		cancel()
+		assert.Equal(t, context.DeadlineExceeded, err)
+	}
+	assert.Equal(t, 0, l.Available())
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		return runtime.NumGoroutine() <= before+2
+	}, time.Second, 10*time.Millisecond, "BorrowCtx leaked its ctx-watcher goroutines")
+}
+
+func TestLimit_BorrowCtxSucceedsOnceTokenIsReturned(t *testing.T) {
+	l := NewLimit(1)
+	l.Borrow()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.BorrowCtx(context.Background())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.NoError(t, l.Return())
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("BorrowCtx never woke up after Return")
+	}
+}
+
+func TestLimit_TryBorrowNAllOrNothing(t *testing.T) {
+	l := NewLimit(3)
+
+	assert.True(t, l.TryBorrowN(2))
+	assert.Equal(t, 2, l.InUse())
+
+	// only 1 left, so borrowing 2 more must fail and leave InUse untouched.
+	assert.False(t, l.TryBorrowN(2))
+	assert.Equal(t, 2, l.InUse())
+
+	assert.True(t, l.TryBorrowN(1))
+	assert.Equal(t, 3, l.InUse())
+	assert.Equal(t, 0, l.Available())
+}
+
+func TestLimit_ObserverReportsBorrowReturnReject(t *testing.T) {
+	observer := &mockObserver{}
+	l := NewLimitWithObserver(1, observer)
+
+	assert.True(t, l.TryBorrow())
+	assert.False(t, l.TryBorrow())
+	assert.NoError(t, l.Return())
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.borrowed))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.returned))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.rejected))
+}
@@ -0,0 +1,86 @@
+package syncx
+
+import "sync"
+
+/**
+ * [rtfsc]
+ * 主题: lockedcalls.go
+ * 摘要: 相同 key 的调用排队串行执行，但各自拿各自的结果
+ * 功能: 与 SingleFlight 的“共享同一次执行结果”不同，LockedCalls 保证同一个 key 的调用一个接一个地执行，
+ *      每次调用都会真正跑一次 fn，只是不会并发执行
+ * 应用: 对同一个 cache key 的写入操作需要避免并发踩踏，但又不能像 SingleFlight 那样把后来者的写入合并成一次
+ * [end]
+ */
+
+type (
+	// LockedCalls lets the concurrent calls with the same key execute one at a time, unlike
+	// SingleFlight where concurrent callers share a single execution's result. Waiting calls
+	// are ordered as sync.Mutex orders its waiters, which is not guaranteed to be arrival order.
+	LockedCalls interface {
+		Do(key string, fn func() (any, error)) (any, error)
+	}
+
+	// [rtfsc]
+	// 每个 key 对应一把互斥锁，ref 记录有多少协程持有或者在排队等待这把锁
+	// ref 归零时，说明已经没有协程会再用到这把锁了，可以从 map 中删除，避免 map 无限增长
+	// [end]
+	keyMutex struct {
+		mu  sync.Mutex
+		ref int
+	}
+
+	lockedCallGroup struct {
+		lock  sync.Mutex
+		mutex map[string]*keyMutex
+	}
+)
+
+// NewLockedCalls returns a LockedCalls.
+func NewLockedCalls() LockedCalls {
+	return &lockedCallGroup{
+		mutex: make(map[string]*keyMutex),
+	}
+}
+
+// [rtfsc]
+// 执行一个任务：
+// 1. 取出（或创建）key 对应的 keyMutex，ref 加一表示多了一个协程在用它
+// 2. 加锁，保证同一个 key 同一时间只有一个 fn 在执行
+// 3. 执行结束后解锁，ref 减一；如果此时 ref 归零，说明没有其他协程在等这把锁了，从 map 中删除
+// [end]
+// Do executes fn, guaranteeing that calls with the same key run one at a time; the order in
+// which waiting calls run is not guaranteed to be the order they arrived in.
+// Unlike SingleFlight, each caller gets the return value of its own execution.
+func (g *lockedCallGroup) Do(key string, fn func() (any, error)) (any, error) {
+	km := g.acquire(key)
+	defer g.release(key, km)
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	return fn()
+}
+
+func (g *lockedCallGroup) acquire(key string) *keyMutex {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	km, ok := g.mutex[key]
+	if !ok {
+		km = new(keyMutex)
+		g.mutex[key] = km
+	}
+	km.ref++
+
+	return km
+}
+
+func (g *lockedCallGroup) release(key string, km *keyMutex) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	km.ref--
+	if km.ref == 0 {
+		delete(g.mutex, key)
+	}
+}
@@ -0,0 +1,64 @@
+package syncx
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockedCalls_SerializesSameKey(t *testing.T) {
+	g := NewLockedCalls()
+
+	const n = 32
+	var running int32
+	var maxRunning int32
+	var calls int32
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			_, _ = g.Do("k", func() (any, error) {
+				cur := atomic.AddInt32(&running, 1)
+				for {
+					max := atomic.LoadInt32(&maxRunning)
+					if cur <= max || atomic.CompareAndSwapInt32(&maxRunning, max, cur) {
+						break
+					}
+				}
+				atomic.AddInt32(&calls, 1)
+				atomic.AddInt32(&running, -1)
+				return nil, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, n, calls)
+	assert.EqualValues(t, 1, maxRunning)
+}
+
+func TestLockedCalls_EachCallerGetsItsOwnResult(t *testing.T) {
+	g := NewLockedCalls()
+
+	const n = 16
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+
+			val, err := g.Do("k", func() (any, error) {
+				return i, nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, i, val)
+		}()
+	}
+	wg.Wait()
+}
@@ -1,8 +1,11 @@
 package syncx
 
 import (
+	"container/list"
+	"errors"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/zeromicro/go-zero/core/errorx"
 )
@@ -21,25 +24,101 @@ import (
  * [end]
  */
 
+// ErrResourceInUse is returned by Remove when the resource is still referenced by GetResourceRef callers.
+var ErrResourceInUse = errors.New("syncx: resource is still in use")
+
+const refFlightPrefix = "ref:"
+
+type (
+	// ManagerOption customizes a ResourceManager created by NewResourceManager.
+	ManagerOption func(*ResourceManager)
+
+	// ReleaseFunc releases a reference to a resource obtained through GetResourceRef.
+	ReleaseFunc func()
+
+	// [rtfsc]
+	// 引用计数资源的元信息
+	// resource 资源本体
+	// ref      当前持有者数量，归零后资源才有可能被关闭
+	// elem     归零后挂到 idle 链表上的节点，用于按最近使用时间淘汰；尚在被持有时为 nil
+	// timer    lazy 模式下，用于在 idle 超过 IdleTTL 后关闭资源的定时器；尚在被持有或 eager 模式下为 nil
+	// [end]
+	refResource struct {
+		resource io.Closer
+		ref      int
+		elem     *list.Element
+		timer    *time.Timer
+	}
+
+	// [rtfsc]
+	// 资源管理器结构：
+	// *resources     存储资源的 map。资源用 io.Closer 接口表示，是一个可关闭的实例 【池化的体现】
+	// *refResources  通过 GetResourceRef 获取的资源，带引用计数和空闲淘汰能力
+	// *idle          refResources 中当前没有被持有的资源，按最近空闲时间排序，用于 MaxResources 超限时的 LRU 淘汰
+	// *singleFlight  用于控制相同的资源的创建操作只有一次 【单例的体现】
+	// *lock          因为 map 不是并发安全的，用于操作 map 时加锁。此处用于直接注入一个现有的资源实例
+	// *idleTTL       lazy 模式下，资源被释放（ref 归零）之后允许空闲的时长，超过后自动关闭；为 0 表示 eager 模式，归零即关闭
+	// *maxResources  refResources 允许同时存在的资源上限，超过时从 idle 中按 LRU 淘汰；为 0 表示不限制
+	// [end]
+	// A ResourceManager is a manager that used to manage resources.
+	ResourceManager struct {
+		resources    map[string]io.Closer
+		refResources map[string]*refResource
+		idle         *list.List
+		singleFlight SingleFlight
+		lock         sync.RWMutex
+		idleTTL      time.Duration
+		maxResources int
+	}
+)
+
+// WithIdleTTL configures how long a reference-counted resource may sit idle (ref count at zero)
+// before it's closed. Without this option, resources are closed eagerly as soon as they become idle.
+func WithIdleTTL(d time.Duration) ManagerOption {
+	return func(manager *ResourceManager) {
+		manager.idleTTL = d
+	}
+}
+
+// WithMaxResources caps the number of reference-counted resources held at once. Once the cap is
+// exceeded, the least recently used idle resource is evicted to make room.
+//
+// This only has an effect in lazy mode (see WithIdleTTL): eager mode closes a resource the
+// instant it becomes idle, so there's never an idle pool for the cap to bound. Combining
+// WithMaxResources with a zero IdleTTL is a configuration mistake, and NewResourceManager
+// panics on it rather than silently doing nothing.
+func WithMaxResources(n int) ManagerOption {
+	return func(manager *ResourceManager) {
+		manager.maxResources = n
+	}
+}
+
 // [rtfsc]
-// 资源管理器结构：
-// *resources    存储资源的 map。资源用 io.Closer 接口表示，是一个可关闭的实例 【池化的体现】
-// *singleFlight 用于控制相同的资源的创建操作只有一次 【单例的体现】
-// *lock         因为 map 不是并发安全的，用于操作 map 时加锁。此处用于直接注入一个现有的资源实例
+// maxResources 依赖 idle 链表来做 LRU 淘汰，而 idle 链表只有在 lazy 模式（IdleTTL>0）下才会
+// 保存空闲资源：eager 模式下 release() 一归零就直接关闭资源，根本不会进入 idle 链表，
+// evictLocked 自然永远没有东西可淘汰。之前的实现在这种组合下会让 WithMaxResources 静默失效，
+// 这里改为在构造时直接 panic，暴露这个配置错误。
 // [end]
-// A ResourceManager is a manager that used to manage resources.
-type ResourceManager struct {
-	resources    map[string]io.Closer
-	singleFlight SingleFlight
-	lock         sync.RWMutex
+func validateOptions(manager *ResourceManager) {
+	if manager.maxResources > 0 && manager.idleTTL <= 0 {
+		panic("syncx: WithMaxResources requires a non-zero WithIdleTTL")
+	}
 }
 
 // NewResourceManager returns a ResourceManager.
-func NewResourceManager() *ResourceManager {
-	return &ResourceManager{
+func NewResourceManager(opts ...ManagerOption) *ResourceManager {
+	manager := &ResourceManager{
 		resources:    make(map[string]io.Closer),
+		refResources: make(map[string]*refResource),
+		idle:         list.New(),
 		singleFlight: NewSingleFlight(),
 	}
+	for _, opt := range opts {
+		opt(manager)
+	}
+	validateOptions(manager)
+
+	return manager
 }
 
 // [rtfsc]
@@ -58,9 +137,19 @@ func (manager *ResourceManager) Close() error {
 			be.Add(err)
 		}
 	}
+	for _, e := range manager.refResources {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		if err := e.resource.Close(); err != nil {
+			be.Add(err)
+		}
+	}
 
 	// release resources to avoid using it later
 	manager.resources = nil
+	manager.refResources = nil
+	manager.idle = nil
 
 	return be.Err()
 }
@@ -102,6 +191,211 @@ func (manager *ResourceManager) GetResource(key string, create func() (io.Closer
 	return val.(io.Closer), nil
 }
 
+// [rtfsc]
+// 获取一个带引用计数的资源，存在则复用并把引用计数加一，不存在则调用 create 创建
+// 返回的 ReleaseFunc 必须在用完资源后调用一次，用于将引用计数减一；减到 0 时：
+//   - eager 模式（未设置 IdleTTL）：立即关闭并从管理器中移除
+//   - lazy 模式（设置了 IdleTTL）：挂到 idle 链表，等待 IdleTTL 到期后才关闭，
+//     期间如果被再次 GetResourceRef 命中，则取消关闭并恢复使用
+//
+// 创建新资源且超过 MaxResources 时，会从 idle 链表淘汰最久未使用的资源腾出名额
+//
+// 注意：命中已有资源、并把 ref 加一这两步必须在同一次持有 manager.lock 期间完成。
+// singleFlight.Do 在返回前已经释放了锁，如果在它返回之后才重新加锁去读 map、ref++，
+// 中间这段空窗期里 release() 完全可能把这个 key 的资源减到 0 并删除，届时 map 里已经
+// 没有这个 key，再对一个不存在的 entry 做 ref++ 就会拿到 nil 指针而 panic；这还不是最
+// 坏情况：因为这段代码当时没有用 defer 释放 manager.lock，panic 发生时锁永远不会被释
+// 放，后续所有对这个 ResourceManager 的调用（无论哪个 key）都会永久阻塞。
+// [end]
+// GetResourceRef returns the resource associated with given key, along with a ReleaseFunc that
+// must be called exactly once when the caller is done with the resource.
+func (manager *ResourceManager) GetResourceRef(key string, create func() (io.Closer, error)) (
+	io.Closer, ReleaseFunc, error) {
+	manager.lock.Lock()
+	if e, ok := manager.refResources[key]; ok {
+		e.ref++
+		manager.activateLocked(e)
+		manager.lock.Unlock()
+		return e.resource, manager.newReleaseFunc(key), nil
+	}
+	manager.lock.Unlock()
+
+	val, err := manager.singleFlight.Do(refFlightPrefix+key, func() (any, error) {
+		manager.lock.RLock()
+		e, ok := manager.refResources[key]
+		manager.lock.RUnlock()
+		if ok {
+			return e.resource, nil
+		}
+
+		return create()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resource := val.(io.Closer)
+
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if e, ok := manager.refResources[key]; ok {
+		e.ref++
+		manager.activateLocked(e)
+		if e.resource != resource {
+			// lost the race to register this key (another goroutine's create won in the
+			// gap between our singleFlight.Do returning and us re-acquiring the lock):
+			// close our surplus resource instead of leaking it.
+			resource.Close()
+		}
+		return e.resource, manager.newReleaseFunc(key), nil
+	}
+
+	e := &refResource{resource: resource, ref: 1}
+	manager.refResources[key] = e
+	manager.evictLocked()
+
+	return resource, manager.newReleaseFunc(key), nil
+}
+
+// [rtfsc]
+// 把一个资源从“空闲即将关闭”的状态恢复成“使用中”：从 idle 链表摘除，停掉即将触发的定时器
+// [end]
+func (manager *ResourceManager) activateLocked(e *refResource) {
+	if e.elem != nil {
+		manager.idle.Remove(e.elem)
+		e.elem = nil
+	}
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+}
+
+// [rtfsc]
+// 生成一个只生效一次的 ReleaseFunc，防止调用方误重复调用导致引用计数多减
+// [end]
+func (manager *ResourceManager) newReleaseFunc(key string) ReleaseFunc {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			manager.release(key)
+		})
+	}
+}
+
+// [rtfsc]
+// 引用计数减一，归零后按 eager/lazy 模式决定是立即关闭还是挂起等待 IdleTTL
+// [end]
+func (manager *ResourceManager) release(key string) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	e, ok := manager.refResources[key]
+	if !ok {
+		return
+	}
+
+	e.ref--
+	if e.ref > 0 {
+		return
+	}
+
+	if manager.idleTTL <= 0 {
+		delete(manager.refResources, key)
+		e.resource.Close()
+		return
+	}
+
+	e.elem = manager.idle.PushFront(key)
+	e.timer = time.AfterFunc(manager.idleTTL, func() {
+		manager.evictIdleByKey(key)
+	})
+}
+
+// [rtfsc]
+// IdleTTL 到期后的回调：仍然要在锁内再次确认资源确实还处于 ref==0 的空闲状态
+// （可能在定时器触发前就已经被重新获取），避免误关闭一个正在使用中的资源
+// [end]
+func (manager *ResourceManager) evictIdleByKey(key string) {
+	manager.lock.Lock()
+	e, ok := manager.refResources[key]
+	if !ok || e.ref > 0 {
+		manager.lock.Unlock()
+		return
+	}
+
+	delete(manager.refResources, key)
+	if e.elem != nil {
+		manager.idle.Remove(e.elem)
+	}
+	manager.lock.Unlock()
+
+	e.resource.Close()
+}
+
+// [rtfsc]
+// 超过 MaxResources 时，从 idle 链表尾部（最久未被使用）开始淘汰，直到回到限制以内或者没有空闲资源可淘汰为止
+// 调用方需持有 manager.lock
+// [end]
+func (manager *ResourceManager) evictLocked() {
+	if manager.maxResources <= 0 {
+		return
+	}
+
+	for len(manager.refResources) > manager.maxResources {
+		back := manager.idle.Back()
+		if back == nil {
+			return
+		}
+
+		key := back.Value.(string)
+		e := manager.refResources[key]
+		manager.idle.Remove(back)
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		delete(manager.refResources, key)
+		e.resource.Close()
+	}
+}
+
+// Remove closes and removes the single resource associated with the given key, without
+// affecting any other resources held by the manager. It returns ErrResourceInUse if the
+// resource was obtained through GetResourceRef and is still referenced by a live caller.
+func (manager *ResourceManager) Remove(key string) error {
+	manager.lock.Lock()
+
+	if e, ok := manager.refResources[key]; ok {
+		if e.ref > 0 {
+			manager.lock.Unlock()
+			return ErrResourceInUse
+		}
+
+		if e.elem != nil {
+			manager.idle.Remove(e.elem)
+		}
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		delete(manager.refResources, key)
+		manager.lock.Unlock()
+
+		return e.resource.Close()
+	}
+
+	resource, ok := manager.resources[key]
+	if !ok {
+		manager.lock.Unlock()
+		return nil
+	}
+
+	delete(manager.resources, key)
+	manager.lock.Unlock()
+
+	return resource.Close()
+}
+
 // Inject injects the resource associated with given key.
 func (manager *ResourceManager) Inject(key string, resource io.Closer) {
 	manager.lock.Lock()
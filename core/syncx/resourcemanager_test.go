@@ -0,0 +1,83 @@
+package syncx
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCloser struct {
+	closed int32
+}
+
+func (c *mockCloser) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestResourceManager_GetResourceRefConcurrent(t *testing.T) {
+	manager := NewResourceManager()
+
+	const n = 32
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				resource, release, err := manager.GetResourceRef("k", func() (io.Closer, error) {
+					return &mockCloser{}, nil
+				})
+				assert.NoError(t, err)
+				assert.NotNil(t, resource)
+				release()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestResourceManager_WithMaxResourcesWithoutIdleTTLPanics(t *testing.T) {
+	assert.Panics(t, func() {
+		NewResourceManager(WithMaxResources(2))
+	})
+}
+
+func TestResourceManager_WithMaxResourcesEvictsLRU(t *testing.T) {
+	manager := NewResourceManager(WithMaxResources(2), WithIdleTTL(time.Hour))
+
+	closers := make(map[string]*mockCloser)
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		key := key
+		closer := &mockCloser{}
+		closers[key] = closer
+
+		_, release, err := manager.GetResourceRef(key, func() (io.Closer, error) {
+			return closer, nil
+		})
+		assert.NoError(t, err)
+		release()
+	}
+
+	assert.EqualValues(t, 1, closers["a"].closed)
+	assert.EqualValues(t, 1, closers["b"].closed)
+	assert.EqualValues(t, 1, closers["c"].closed)
+	assert.EqualValues(t, 0, closers["d"].closed)
+	assert.EqualValues(t, 0, closers["e"].closed)
+}
+
+func TestResourceManager_RemoveWhileInUse(t *testing.T) {
+	manager := NewResourceManager()
+
+	_, release, err := manager.GetResourceRef("k", func() (io.Closer, error) {
+		return &mockCloser{}, nil
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, ErrResourceInUse, manager.Remove("k"))
+	release()
+	assert.NoError(t, manager.Remove("k"))
+}
@@ -0,0 +1,42 @@
+package syncx
+
+import "io"
+
+/**
+ * [rtfsc]
+ * 主题: resourcemanagerg.go
+ * 摘要: ResourceManager 的泛型包装
+ * 功能: 调用方拿到的就是具体的 T（必须实现 io.Closer），不用再自己写 val.(SomeType) 做类型断言
+ * [end]
+ */
+
+// ResourceManagerG is a type-parameterized wrapper around ResourceManager, so callers don't
+// have to assert the io.Closer result back to their own resource type.
+type ResourceManagerG[T io.Closer] struct {
+	manager *ResourceManager
+}
+
+// NewResourceManagerG returns a ResourceManagerG.
+func NewResourceManagerG[T io.Closer](opts ...ManagerOption) ResourceManagerG[T] {
+	return ResourceManagerG[T]{
+		manager: NewResourceManager(opts...),
+	}
+}
+
+// GetResource is like ResourceManager.GetResource, but returns a T instead of an io.Closer.
+func (m ResourceManagerG[T]) GetResource(key string, create func() (T, error)) (T, error) {
+	val, err := m.manager.GetResource(key, func() (io.Closer, error) {
+		return create()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return val.(T), nil
+}
+
+// Close closes the underlying ResourceManager.
+func (m ResourceManagerG[T]) Close() error {
+	return m.manager.Close()
+}
@@ -0,0 +1,36 @@
+package syncx
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceManagerG_GetResourceConcurrent(t *testing.T) {
+	manager := NewResourceManagerG[*mockCloser]()
+	defer manager.Close()
+
+	const n = 32
+	var wg sync.WaitGroup
+	results := make([]*mockCloser, n)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+
+			resource, err := manager.GetResource("k", func() (*mockCloser, error) {
+				return &mockCloser{}, nil
+			})
+			assert.NoError(t, err)
+			results[i] = resource
+		}()
+	}
+	wg.Wait()
+
+	for _, resource := range results {
+		assert.Same(t, results[0], resource)
+	}
+}
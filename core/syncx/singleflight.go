@@ -1,6 +1,12 @@
 package syncx
 
-import "sync"
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
 
 /**
  * [rtfsc]
@@ -21,18 +27,38 @@ type (
 	SingleFlight interface {
 		Do(key string, fn func() (any, error)) (any, error)
 		DoEx(key string, fn func() (any, error)) (any, bool, error)
+		DoChan(key string, fn func() (any, error)) <-chan Result
+		Forget(key string)
+	}
+
+	// Result is the result of a call delivered through the channel returned by DoChan.
+	Result struct {
+		Val    any
+		Err    error
+		Shared bool
+	}
+
+	// PanicError wraps a panic recovered from fn, so that it can be re-panicked in every
+	// waiter, instead of only being observed by the goroutine that happened to run fn.
+	PanicError struct {
+		Value any
+		Stack []byte
 	}
 
 	// [rtfsc]
 	// 表示一次任务
-	// wg  用于等待任务执行结束
-	// val 任务执行的返回值
-	// err 任务执行的错误
+	// wg    用于等待任务执行结束
+	// val   任务执行的返回值
+	// err   任务执行的错误
+	// dups  有多少个协程共享了本次任务的结果，用于计算 Result.Shared
+	// chans 通过 DoChan 订阅本次任务结果的 channel，任务结束后逐一通知
 	// [end]
 	call struct {
-		wg  sync.WaitGroup
-		val any
-		err error
+		wg    sync.WaitGroup
+		val   any
+		err   error
+		dups  int
+		chans []chan<- Result
 	}
 
 	// [rtfsc]
@@ -46,6 +72,19 @@ type (
 	}
 )
 
+// errGoexit marks that fn called runtime.Goexit instead of returning normally, so that
+// makeCall can tell that case apart from a panic.
+var errGoexit = errors.New("singleflight: abnormal termination of fn, runtime.Goexit was called")
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v\n\n%s", e.Value, e.Stack)
+}
+
+func newPanicError(v any) error {
+	return &PanicError{Value: v, Stack: debug.Stack()}
+}
+
 // NewSingleFlight returns a SingleFlight.
 func NewSingleFlight() SingleFlight {
 	return &flightGroup{
@@ -83,6 +122,47 @@ func (g *flightGroup) DoEx(key string, fn func() (any, error)) (val any, fresh b
 	return c.val, true, c.err
 }
 
+// [rtfsc]
+// 执行一个任务，与 Do 不同的是不会阻塞调用方，而是立即返回一个 channel，任务结束后结果写入其中
+// 适合需要配合 select 监听 context 取消，或者同时订阅多个 key 结果的场景
+// Shared 表示该结果是否被多个调用方共享（即有其他协程加入了本次任务）
+// [end]
+// DoChan is like Do but returns a channel that will receive the result when the call completes.
+func (g *flightGroup) DoChan(key string, fn func() (any, error)) <-chan Result {
+	ch := make(chan Result, 1)
+
+	g.lock.Lock()
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.lock.Unlock()
+		return ch
+	}
+
+	c := new(call)
+	c.wg.Add(1)
+	c.chans = append(c.chans, ch)
+	g.calls[key] = c
+	g.lock.Unlock()
+
+	go g.makeCall(c, key, fn)
+
+	return ch
+}
+
+// [rtfsc]
+// 让指定 key 的下一次调用重新执行 fn，而不是复用当前/历史的结果
+// 只是把 key 从 map 中移除，不会等待、也不会影响正在等待该 call 完成的协程
+// 适合 fn 已知返回了脏数据（例如被污染的缓存），需要主动让结果失效的场景
+// [end]
+// Forget tells the SingleFlight to forget about the given key. Future calls with this key
+// will not wait for any pending call and will execute fn freshly.
+func (g *flightGroup) Forget(key string) {
+	g.lock.Lock()
+	delete(g.calls, key)
+	g.lock.Unlock()
+}
+
 // [rtfsc]
 // 创建或者获取一个已经存在的 call，返回其引用
 // c    call 的引用
@@ -96,8 +176,10 @@ func (g *flightGroup) DoEx(key string, fn func() (any, error)) (val any, fresh b
 func (g *flightGroup) createCall(key string) (c *call, done bool) {
 	g.lock.Lock()
 	if c, ok := g.calls[key]; ok {
+		c.dups++
 		g.lock.Unlock()
 		c.wg.Wait()
+		awaitTermination(c)
 		return c, true
 	}
 
@@ -110,15 +192,76 @@ func (g *flightGroup) createCall(key string) (c *call, done bool) {
 }
 
 // [rtfsc]
-// 实际执行 call, 执行完成后，删除 map 中的 call
+// 等待的一方（非发起方）在拿到结果之后，如果发现发起方是因为 panic 或者 runtime.Goexit 结束的，
+// 也要让自己以同样的方式终止，而不是吞掉异常、悄悄返回一个零值
+// [end]
+func awaitTermination(c *call) {
+	if e, ok := c.err.(*PanicError); ok {
+		panic(e)
+	}
+	if c.err == errGoexit {
+		runtime.Goexit()
+	}
+}
+
+// [rtfsc]
+// 实际执行 call，执行完成后，删除 map 中的 call，并把结果广播给所有通过 DoChan 订阅的 channel
+//
+// 这里额外处理了 fn 异常终止的两种情况：
+//  1. fn 发生 panic：记录下 panic 的内容和堆栈，发起方在清理完 call 之后重新 panic，
+//     等待方在 wg.Wait() 返回之后（见 awaitTermination）也重新 panic，确保没有一个协程会得到一个被悄悄吞掉异常的零值
+//  2. fn 调用了 runtime.Goexit：没有 panic，也没有正常返回，借助 normalReturn/recovered
+//     两个标记识别出这种情况，发起方和等待方都跟着调用 runtime.Goexit
+//
+// 注意 chans 的广播必须排在 panic/Goexit 重新触发之前：runtime.Goexit() 不会返回，一旦
+// 执行到它，后面的代码（包括广播循环）永远不会被执行到，那么所有阻塞在 DoChan 返回的
+// channel 上等待结果的协程就会永远收不到 Result、永久挂起（不像 panic 那样至少会让进程
+// 崩溃，这是一个不会被发现的 goroutine 泄漏）。所以必须先广播结果，再重新 panic / Goexit。
 // [end]
 func (g *flightGroup) makeCall(c *call, key string, fn func() (any, error)) {
+	normalReturn := false
+	recovered := false
+
 	defer func() {
+		if !normalReturn && !recovered {
+			c.err = errGoexit
+		}
+
 		g.lock.Lock()
-		delete(g.calls, key)
+		// after Forget, the key may already belong to a newer call, which must stay in the map
+		if g.calls[key] == c {
+			delete(g.calls, key)
+		}
+		chans := c.chans
 		g.lock.Unlock()
 		c.wg.Done()
+
+		for _, ch := range chans {
+			ch <- Result{Val: c.val, Err: c.err, Shared: c.dups > 0}
+		}
+
+		if e, ok := c.err.(*PanicError); ok {
+			panic(e)
+		}
+		if c.err == errGoexit {
+			runtime.Goexit()
+		}
 	}()
 
-	c.val, c.err = fn()
+	func() {
+		defer func() {
+			if !normalReturn {
+				if r := recover(); r != nil {
+					c.err = newPanicError(r)
+				}
+			}
+		}()
+
+		c.val, c.err = fn()
+		normalReturn = true
+	}()
+
+	if !normalReturn {
+		recovered = true
+	}
 }
@@ -0,0 +1,171 @@
+package syncx
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlightGroup_DoPanic(t *testing.T) {
+	g := NewSingleFlight()
+
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var panicked int
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					panicked++
+					mu.Unlock()
+				}
+			}()
+
+			_, _ = g.Do("any", func() (any, error) {
+				panic("fn panicked")
+			})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, n, panicked)
+}
+
+func TestFlightGroup_DoGoexit(t *testing.T) {
+	g := NewSingleFlight()
+
+	const n = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var exited, reachedAfterDo int
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer func() {
+				mu.Lock()
+				exited++
+				mu.Unlock()
+				wg.Done()
+			}()
+
+			_, _ = g.Do("any", func() (any, error) {
+				runtime.Goexit()
+				return nil, nil
+			})
+
+			// unreachable: Goexit must unwind through Do before this line runs
+			mu.Lock()
+			reachedAfterDo++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, n, exited)
+	assert.Equal(t, 0, reachedAfterDo)
+}
+
+func TestFlightGroup_DoChanSharedFlag(t *testing.T) {
+	g := NewSingleFlight()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	lone := g.DoChan("lone", func() (any, error) {
+		close(started)
+		<-release
+		return "lone-val", nil
+	})
+	<-started
+	close(release)
+
+	res := <-lone
+	assert.NoError(t, res.Err)
+	assert.Equal(t, "lone-val", res.Val)
+	assert.False(t, res.Shared)
+
+	release = make(chan struct{})
+	started = make(chan struct{})
+
+	first := g.DoChan("shared", func() (any, error) {
+		close(started)
+		<-release
+		return "shared-val", nil
+	})
+	<-started
+	second := g.DoChan("shared", func() (any, error) {
+		panic("must not be called, should join the in-flight call instead")
+	})
+	close(release)
+
+	firstRes := <-first
+	secondRes := <-second
+	assert.NoError(t, firstRes.Err)
+	assert.NoError(t, secondRes.Err)
+	assert.Equal(t, "shared-val", firstRes.Val)
+	assert.Equal(t, "shared-val", secondRes.Val)
+	assert.True(t, firstRes.Shared)
+	assert.True(t, secondRes.Shared)
+}
+
+func TestFlightGroup_ForgetExecutesFnAgain(t *testing.T) {
+	g := NewSingleFlight()
+
+	var calls int32
+	fn := func() (any, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	val, err := g.Do("k", fn)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, val)
+
+	// without Forget, the key is already gone from the map (the previous call completed),
+	// so the next Do would execute fn fresh anyway; Forget's contract is that it also
+	// invalidates a call that is still in flight.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	inFlight := g.DoChan("k", func() (any, error) {
+		close(started)
+		<-release
+		return atomic.AddInt32(&calls, 1), nil
+	})
+	<-started
+
+	g.Forget("k")
+
+	val2, err2 := g.Do("k", fn)
+	assert.NoError(t, err2)
+	assert.EqualValues(t, 2, val2)
+
+	close(release)
+	inFlightRes := <-inFlight
+	assert.NoError(t, inFlightRes.Err)
+	assert.EqualValues(t, 3, inFlightRes.Val)
+}
+
+func TestFlightGroup_DoChanGoexitNotifiesSubscribers(t *testing.T) {
+	g := NewSingleFlight()
+
+	ch := g.DoChan("any", func() (any, error) {
+		runtime.Goexit()
+		return nil, nil
+	})
+
+	select {
+	case res := <-ch:
+		assert.Equal(t, errGoexit, res.Err)
+	case <-time.After(time.Second):
+		t.Fatal("DoChan subscriber never received a result after fn called runtime.Goexit")
+	}
+}
@@ -0,0 +1,52 @@
+package syncx
+
+/**
+ * [rtfsc]
+ * 主题: singleflightg.go
+ * 摘要: SingleFlight 的泛型包装
+ * 功能: 在 SingleFlight 之上加一层类型参数，调用方拿到的就是 T，而不用像以前那样每次自己写 val.(T) 做类型断言
+ *      （断言写错类型的话，只有运行到时才会 panic，泛型能把这类问题提前到编译期）
+ * [end]
+ */
+
+// SingleFlightG is a type-parameterized wrapper around SingleFlight, so callers don't have to
+// assert the any result back to their own type.
+type SingleFlightG[T any] struct {
+	flight SingleFlight
+}
+
+// NewSingleFlightG returns a SingleFlightG.
+func NewSingleFlightG[T any]() SingleFlightG[T] {
+	return SingleFlightG[T]{
+		flight: NewSingleFlight(),
+	}
+}
+
+// Do is like SingleFlight.Do, but returns a T instead of any.
+func (g SingleFlightG[T]) Do(key string, fn func() (T, error)) (T, error) {
+	val, err := g.flight.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	// fn returns a T, so the assertion only fails for a nil interface T, whose zero value is kept
+	v, _ := val.(T)
+	return v, nil
+}
+
+// DoEx is like SingleFlight.DoEx, but returns a T instead of any.
+func (g SingleFlightG[T]) DoEx(key string, fn func() (T, error)) (T, bool, error) {
+	val, fresh, err := g.flight.DoEx(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, fresh, err
+	}
+
+	v, _ := val.(T)
+	return v, fresh, nil
+}
@@ -0,0 +1,49 @@
+package syncx
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleFlightG_Do(t *testing.T) {
+	g := NewSingleFlightG[int]()
+
+	const n = 32
+	var calls int32
+	var wg sync.WaitGroup
+	results := make([]int, n)
+
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+
+			val, err := g.Do("k", func() (int, error) {
+				calls++
+				return 42, nil
+			})
+			assert.NoError(t, err)
+			results[i] = val
+		}()
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, calls, int32(1))
+	for _, val := range results {
+		assert.Equal(t, 42, val)
+	}
+}
+
+func TestSingleFlightG_DoEx(t *testing.T) {
+	g := NewSingleFlightG[string]()
+
+	val, fresh, err := g.DoEx("k", func() (string, error) {
+		return "v", nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, fresh)
+	assert.Equal(t, "v", val)
+}